@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// BaselineEntry is one accepted finding recorded in a baseline file.
+// Entries are matched by rule, file, and a normalized code snippet
+// rather than line number, so that edits above a finding don't
+// invalidate it.
+type BaselineEntry struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Snippet string `json:"snippet"`
+}
+
+var nomigrateRe = regexp.MustCompile(`//\s*nomigrate(?::([A-Za-z0-9_/-]+))?\b`)
+
+// loadBaseline reads a baseline file, returning the set of accepted
+// entries keyed by rule+file+snippet. A missing file is not an error;
+// callers use that to distinguish "no baseline yet" from "baseline with
+// nothing in it".
+func loadBaseline(path string) (map[string]bool, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entries []BaselineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, false, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+
+	accepted := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		accepted[baselineKey(e.Rule, e.File, e.Snippet)] = true
+	}
+	return accepted, true, nil
+}
+
+// writeBaseline records findings as the accepted baseline.
+func writeBaseline(path string, findings []Finding) error {
+	entries := make([]BaselineEntry, 0, len(findings))
+	for _, f := range findings {
+		snippet, err := readSpecificLine(f.File, f.Line)
+		if err != nil {
+			snippet = ""
+		}
+		entries = append(entries, BaselineEntry{
+			Rule:    f.Rule,
+			File:    f.File,
+			Snippet: normalizeSnippet(snippet),
+		})
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func baselineKey(rule, file, snippet string) string {
+	return rule + "\x00" + file + "\x00" + normalizeSnippet(snippet)
+}
+
+func normalizeSnippet(snippet string) string {
+	return strings.Join(strings.Fields(snippet), " ")
+}
+
+// filterAgainstBaseline drops findings already present in the accepted
+// set, returning the findings that are new.
+func filterAgainstBaseline(findings []Finding, accepted map[string]bool) []Finding {
+	var fresh []Finding
+	for _, f := range findings {
+		snippet, err := readSpecificLine(f.File, f.Line)
+		if err != nil {
+			snippet = ""
+		}
+		if accepted[baselineKey(f.Rule, f.File, snippet)] {
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	return fresh
+}
+
+// filterNoMigrateDirectives drops findings whose offending line, or the
+// line immediately above it, carries a "//nomigrate" or
+// "//nomigrate:RULE_ID" comment. It logs a summary of how many findings
+// were suppressed this way.
+func filterNoMigrateDirectives(logger *slog.Logger, findings []Finding) []Finding {
+	fileLines := map[string][]string{}
+	kept := make([]Finding, 0, len(findings))
+	suppressed := 0
+
+	for _, f := range findings {
+		lines, ok := fileLines[f.File]
+		if !ok {
+			lines = readAllLines(f.File)
+			fileLines[f.File] = lines
+		}
+
+		if noMigrateSuppresses(lines, f.Line, f.Rule) {
+			suppressed++
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	if suppressed > 0 {
+		logger.Info("suppressed findings via //nomigrate directive", "count", suppressed)
+	}
+	return kept
+}
+
+func noMigrateSuppresses(lines []string, line int, rule string) bool {
+	for _, idx := range []int{line - 1, line - 2} {
+		if idx < 0 || idx >= len(lines) {
+			continue
+		}
+		m := nomigrateRe.FindStringSubmatch(lines[idx])
+		if m == nil {
+			continue
+		}
+		if m[1] == "" || m[1] == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSarifByFindings rebuilds sarif keeping only the locations that
+// correspond to an entry in findings, dropping results left with no
+// locations. It's used to bring the reported SARIF back in sync after
+// baseline/suppression filtering has thinned the finding list.
+func filterSarifByFindings(sarif *Sarif, findings []Finding) *Sarif {
+	keep := make(map[string]bool, len(findings))
+	for _, f := range findings {
+		keep[fmt.Sprintf("%s\x00%s\x00%d", f.Rule, f.File, f.Line)] = true
+	}
+
+	out := &Sarif{}
+	for _, run := range sarif.Runs {
+		newRun := SarifRun{}
+		for _, result := range run.Results {
+			var locations []SarifLocation
+			for _, loc := range result.Locations {
+				key := fmt.Sprintf("%s\x00%s\x00%d", result.RuleId,
+					loc.PhysicalLocation.ArtifactLocation.Uri, loc.PhysicalLocation.Region.StartLine)
+				if keep[key] {
+					locations = append(locations, loc)
+				}
+			}
+			if len(locations) > 0 {
+				newResult := result
+				newResult.Locations = locations
+				newRun.Results = append(newRun.Results, newResult)
+			}
+		}
+		out.Runs = append(out.Runs, newRun)
+	}
+	return out
+}
+
+func readAllLines(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
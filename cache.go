@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultCacheDir returns ~/.cache/cosmos-migration, the default home
+// for cached findings when -cache-dir isn't set.
+func defaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "cosmos-migration")
+	}
+	return filepath.Join(home, ".cache", "cosmos-migration")
+}
+
+// cacheKey hashes the inputs that determine whether a prior SARIF
+// result is still valid for this invocation: the source tree, the
+// CodeQL pack being run, and any custom build command. The source tree
+// is identified by its git commit (plus any uncommitted changes) when
+// dir is inside a git repo, and by hashing the concatenated Go source
+// files otherwise. The pack is identified by its resolved on-disk
+// version/content rather than its name, so upgrading the installed
+// pack invalidates old entries even though packRef doesn't change.
+func cacheKey(dir, packRef, customBuildCommand string) (string, error) {
+	sourceID, err := sourceTreeID(dir)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(sourceID))
+	h.Write([]byte{0})
+	h.Write([]byte(resolvePackVersion(packRef)))
+	h.Write([]byte{0})
+	h.Write([]byte(customBuildCommand))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceTreeID identifies the state of dir: the current git commit if
+// dir is a git working tree, folded together with a hash of any
+// uncommitted changes (`git status --porcelain` plus `git diff HEAD`)
+// so a dirty tree doesn't collide with the commit it's based on. Falls
+// back to a content hash of its Go source files outside a git repo.
+func sourceTreeID(dir string) (string, error) {
+	head, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return hashGoSourceTree(dir)
+	}
+	commit := strings.TrimSpace(string(head))
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if err != nil {
+		return "", err
+	}
+	if len(strings.TrimSpace(string(status))) == 0 {
+		return commit, nil
+	}
+
+	diff, err := exec.Command("git", "-C", dir, "diff", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(commit))
+	h.Write(status)
+	h.Write(diff)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolvePackVersion looks up the installed CodeQL pack named packRef
+// and hashes its qlpack.yml so a pack upgrade (new version, pinned
+// commit, etc.) changes the cache key even though packRef itself is a
+// fixed name. Falls back to packRef verbatim if the pack can't be
+// resolved, e.g. codeql isn't installed in this environment.
+func resolvePackVersion(packRef string) string {
+	out, err := exec.Command("codeql", "resolve", "qlpacks", "--format=json").Output()
+	if err != nil {
+		return packRef
+	}
+
+	var packs map[string][]string
+	if err := json.Unmarshal(out, &packs); err != nil {
+		return packRef
+	}
+
+	paths, ok := packs[packRef]
+	if !ok || len(paths) == 0 {
+		return packRef
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(paths[0], "qlpack.yml"))
+	if err != nil {
+		return packRef
+	}
+
+	sum := sha256.Sum256(manifest)
+	return packRef + "@" + hex.EncodeToString(sum[:])
+}
+
+func hashGoSourceTree(dir string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(f))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func cachePath(cacheDir, key string) string {
+	return filepath.Join(cacheDir, key+".sarif.json")
+}
+
+// loadCachedSarif returns the raw SARIF bytes and parsed results stored
+// under key, if present.
+func loadCachedSarif(cacheDir, key string) ([]byte, *Sarif, bool) {
+	data, err := os.ReadFile(cachePath(cacheDir, key))
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var sarif Sarif
+	if err := json.Unmarshal(data, &sarif); err != nil {
+		return nil, nil, false
+	}
+
+	return data, &sarif, true
+}
+
+func storeCachedSarif(logger *slog.Logger, cacheDir, key string, rawSarif []byte) {
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		logger.Warn("failed to create cache dir", "cache_dir", cacheDir, "error", err)
+		return
+	}
+	if err := os.WriteFile(cachePath(cacheDir, key), rawSarif, 0o644); err != nil {
+		logger.Warn("failed to write cache entry", "cache_dir", cacheDir, "error", err)
+	}
+}
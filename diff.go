@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lineRange is an inclusive [start, end] range of changed lines in a
+// file's current (post-diff) version.
+type lineRange struct {
+	start, end int
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLineRanges runs `git diff --unified=0 baseRef` against dir and
+// returns, per file (relative to dir), the line ranges added or
+// modified in the working tree relative to baseRef.
+func changedLineRanges(dir, baseRef string) (map[string][]lineRange, error) {
+	cmd := exec.Command("git", "-C", dir, "diff", "--unified=0", "--relative", baseRef)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseUnifiedDiffRanges(string(out)), nil
+}
+
+// parseUnifiedDiffRanges parses the output of `git diff --unified=0`
+// into per-file line ranges touched in the new (post-diff) version of
+// each file. A hunk whose new-file line count is 0 is a pure deletion —
+// it removes lines without adding or changing any, so it has no
+// corresponding range in the new file and is skipped.
+func parseUnifiedDiffRanges(diffOutput string) map[string][]lineRange {
+	ranges := map[string][]lineRange{}
+	var currentFile string
+
+	for _, line := range strings.Split(diffOutput, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			path := strings.TrimPrefix(line, "+++ ")
+			path = strings.TrimPrefix(path, "b/")
+			currentFile = path
+		case strings.HasPrefix(line, "@@ "):
+			m := hunkHeaderRe.FindStringSubmatch(line)
+			if m == nil || currentFile == "" {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count == 0 {
+				continue
+			}
+			ranges[currentFile] = append(ranges[currentFile], lineRange{start: start, end: start + count - 1})
+		}
+	}
+	return ranges
+}
+
+// filterByDiff keeps only the findings whose line falls inside a
+// changed range for their file.
+func filterByDiff(findings []Finding, ranges map[string][]lineRange) []Finding {
+	var kept []Finding
+	for _, f := range findings {
+		for _, r := range ranges[f.File] {
+			if f.Line >= r.start && f.Line <= r.end {
+				kept = append(kept, f)
+				break
+			}
+		}
+	}
+	return kept
+}
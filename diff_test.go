@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnifiedDiffRanges(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want map[string][]lineRange
+	}{
+		{
+			name: "pure addition",
+			diff: `diff --git a/foo.go b/foo.go
+--- a/foo.go
++++ b/foo.go
+@@ -10,0 +11,3 @@ func foo() {
++line one
++line two
++line three
+`,
+			want: map[string][]lineRange{
+				"foo.go": {{start: 11, end: 13}},
+			},
+		},
+		{
+			name: "pure deletion has no range in the new file",
+			diff: `diff --git a/foo.go b/foo.go
+--- a/foo.go
++++ b/foo.go
+@@ -5,3 +4,0 @@ func foo() {
+-line one
+-line two
+-line three
+`,
+			want: map[string][]lineRange{},
+		},
+		{
+			name: "multiple hunks across multiple files",
+			diff: `diff --git a/foo.go b/foo.go
+--- a/foo.go
++++ b/foo.go
+@@ -1,2 +1,1 @@
+-old line
++new line
+@@ -20,0 +21,2 @@
++added one
++added two
+diff --git a/bar.go b/bar.go
+--- a/bar.go
++++ b/bar.go
+@@ -7 +7 @@
+-old bar
++new bar
+`,
+			want: map[string][]lineRange{
+				"foo.go": {{start: 1, end: 1}, {start: 21, end: 22}},
+				"bar.go": {{start: 7, end: 7}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseUnifiedDiffRanges(tt.diff)
+			if len(got) == 0 {
+				got = map[string][]lineRange{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUnifiedDiffRanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByDiff(t *testing.T) {
+	ranges := map[string][]lineRange{
+		"foo.go": {{start: 10, end: 12}},
+	}
+	findings := []Finding{
+		{File: "foo.go", Line: 11},
+		{File: "foo.go", Line: 20},
+		{File: "bar.go", Line: 11},
+	}
+
+	got := filterByDiff(findings, ranges)
+	if len(got) != 1 || got[0].Line != 11 || got[0].File != "foo.go" {
+		t.Fatalf("filterByDiff() = %+v, want only foo.go:11", got)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+)
+
+// Fixer mutates file in place to migrate the construct flagged by a
+// single finding anchored at region. It returns whether it actually
+// changed anything, so a rule that didn't match the AST at that
+// location (e.g. the line moved) can be skipped without error.
+type Fixer func(fset *token.FileSet, file *ast.File, region SarifRegion) bool
+
+// fixers is the catalog of rule-specific rewriters available to -fix.
+// Add an entry here, alongside the corresponding QL rule in
+// skip-mev/cosmos-52-ql, to teach the tool a new v0.50->v0.52 codemod.
+var fixers = map[string]Fixer{
+	"go/renamed-import-path": fixRenamedImport,
+	"go/removed-context-arg": fixRemovedContextArg,
+}
+
+// renamedImportPaths maps Cosmos SDK v0.50 import paths to their
+// v0.52 replacements for fixRenamedImport.
+var renamedImportPaths = map[string]string{
+	"github.com/cosmos/cosmos-sdk/store/types": "cosmossdk.io/store/types",
+	"github.com/cosmos/cosmos-sdk/store":       "cosmossdk.io/store",
+}
+
+// regionMatchesPos reports whether pos is the exact start position a
+// SARIF region is anchored to. Matching on column as well as line is
+// what lets a fixer pick the one flagged node when several candidates
+// share a source line.
+func regionMatchesPos(fset *token.FileSet, pos token.Pos, region SarifRegion) bool {
+	p := fset.Position(pos)
+	return p.Line == region.StartLine && p.Column == region.StartColumn
+}
+
+// fixRenamedImport rewrites the import path at region to its v0.52
+// equivalent, if one is known. It matches on the path string's own
+// position rather than the ImportSpec's: for an aliased import (e.g.
+// `st "github.com/..."`), imp.Pos() resolves to the alias identifier,
+// several columns before the path CodeQL actually anchors the finding on.
+func fixRenamedImport(fset *token.FileSet, file *ast.File, region SarifRegion) bool {
+	for _, imp := range file.Imports {
+		if !regionMatchesPos(fset, imp.Path.Pos(), region) {
+			continue
+		}
+		oldPath := imp.Path.Value
+		unquoted := oldPath[1 : len(oldPath)-1]
+		newPath, ok := renamedImportPaths[unquoted]
+		if !ok {
+			continue
+		}
+		imp.Path.Value = fmt.Sprintf("%q", newPath)
+		return true
+	}
+	return false
+}
+
+// fixRemovedContextArg drops a leading "ctx" argument from the call
+// expression at region, matching constructors that no longer take a
+// context in v0.52 (e.g. store/keeper constructors). It matches the
+// call's exact start column so that two ctx-taking calls sharing a
+// line (e.g. a multi-value return) don't both get rewritten when only
+// one was flagged.
+func fixRemovedContextArg(fset *token.FileSet, file *ast.File, region SarifRegion) bool {
+	changed := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if changed {
+			return false
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if !regionMatchesPos(fset, call.Pos(), region) {
+			return true
+		}
+		if len(call.Args) == 0 {
+			return true
+		}
+		ident, ok := call.Args[0].(*ast.Ident)
+		if !ok || ident.Name != "ctx" {
+			return true
+		}
+		call.Args = call.Args[1:]
+		changed = true
+		return false
+	})
+	return changed
+}
+
+// runFix applies the registered fixers to every finding in sarif whose
+// rule has one, grouped by file so each source file is parsed once.
+// mode "dry-run" prints a unified diff per changed file; "apply"
+// rewrites the file in place and leaves a .bak sidecar of the original.
+func runFix(logger *slog.Logger, sarif *Sarif, mode string) error {
+	byFile := map[string][]SarifResult{}
+	for _, run := range sarif.Runs {
+		for _, result := range run.Results {
+			if _, ok := fixers[result.RuleId]; !ok {
+				continue
+			}
+			for _, loc := range result.Locations {
+				uri := loc.PhysicalLocation.ArtifactLocation.Uri
+				byFile[uri] = append(byFile[uri], result)
+			}
+		}
+	}
+
+	files := make([]string, 0, len(byFile))
+	for f := range byFile {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	for _, path := range files {
+		if err := fixFile(logger, path, byFile[path], mode); err != nil {
+			return fmt.Errorf("failed to fix %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func fixFile(logger *slog.Logger, path string, results []SarifResult, mode string) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, original, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, result := range results {
+		fixer := fixers[result.RuleId]
+		for _, loc := range result.Locations {
+			if fixer(fset, file, loc.PhysicalLocation.Region) {
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return err
+	}
+
+	if mode == "dry-run" {
+		return printUnifiedDiff(path, original, buf.Bytes())
+	}
+
+	if err := os.WriteFile(path+".bak", original, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	logger.Info("applied fix", "file", path, "backup", path+".bak")
+	return nil
+}
+
+// printUnifiedDiff shells out to the system diff tool to render a
+// unified diff; this tool has no other dependency on external
+// diffing utilities, so if diff isn't available it falls back to
+// printing both versions labeled.
+func printUnifiedDiff(path string, before, after []byte) error {
+	beforeFile, err := os.CreateTemp("", "nomigrate-before-*.go")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(beforeFile.Name())
+	if _, err := beforeFile.Write(before); err != nil {
+		return err
+	}
+	beforeFile.Close()
+
+	afterFile, err := os.CreateTemp("", "nomigrate-after-*.go")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(afterFile.Name())
+	if _, err := afterFile.Write(after); err != nil {
+		return err
+	}
+	afterFile.Close()
+
+	cmd := exec.Command("diff", "-u", beforeFile.Name(), afterFile.Name())
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		fmt.Printf("--- %s (original)\n%s\n+++ %s (fixed)\n%s\n", path, before, path, after)
+		return nil
+	}
+	if len(out) == 0 {
+		return nil
+	}
+
+	fmt.Printf("--- %s\n+++ %s (fixed)\n", path, path)
+	_, err = io.WriteString(os.Stdout, string(out))
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// newLogger builds the slog.Logger used throughout the tool from the
+// -log-level and -log-format flags.
+func newLogger(level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	switch level {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info", "":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text", "":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return slog.New(handler), nil
+}
+
+// runLoggedCommand runs cmd, streaming each line of its stdout/stderr
+// through logger as a structured record tagged with stage and db_path,
+// and logs the overall duration once the subprocess exits.
+func runLoggedCommand(logger *slog.Logger, cmd *exec.Cmd, stage, dbPath string) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		streamLines(logger, stdout, slog.LevelInfo, stage, dbPath)
+		done <- struct{}{}
+	}()
+	go func() {
+		streamLines(logger, stderr, slog.LevelWarn, stage, dbPath)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+
+	err = cmd.Wait()
+	logger.Info("command finished",
+		"stage", stage,
+		"db_path", dbPath,
+		"duration_ms", time.Since(start).Milliseconds(),
+		"error", errString(err),
+	)
+	return err
+}
+
+func streamLines(logger *slog.Logger, r io.Reader, level slog.Level, stage, dbPath string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		logger.Log(context.Background(), level, scanner.Text(), "stage", stage, "db_path", dbPath)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
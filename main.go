@@ -3,9 +3,12 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/fatih/color"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path"
@@ -21,6 +24,8 @@ type SarifRun struct {
 }
 
 type SarifResult struct {
+	RuleId    string `json:"ruleId"`
+	Level     string `json:"level"`
 	Message   SarifMessage
 	Locations []SarifLocation
 }
@@ -48,26 +53,91 @@ type SarifArtifactLocation struct {
 	Uri string
 }
 
+// codeQLPackRef is the CodeQL query pack this tool always runs.
+const codeQLPackRef = "skip-mev/cosmos-52-ql"
+
 type Finding struct {
 	Rule     string `json:"rule"`
 	Message  string `json:"message"`
 	File     string `json:"file"`
 	Line     int    `json:"line"`
+	Column   int    `json:"column"`
 	Severity string `json:"severity"`
 }
 
 func main() {
 	dir := flag.String("dir", ".", "Directory to analyze")
 	command := flag.String("command", "", "Custom build command")
+	format := flag.String("format", "text", "Output format: text, sarif, json, junit-xml, github-actions, sonarqube")
+	output := flag.String("output", "stdout", "Where to write findings: stdout, stderr, or a file path")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn, error")
+	logFormat := flag.String("log-format", "text", "Log format: text, json")
+	cacheDir := flag.String("cache-dir", defaultCacheDir(), "Directory for cached findings")
+	noCache := flag.Bool("no-cache", false, "Disable the findings cache")
+	refreshCache := flag.Bool("refresh-cache", false, "Ignore any cached findings and re-run analysis")
+	baseline := flag.String("baseline", "", "Path to a baseline file of accepted findings; only new findings fail the run")
+	fix := flag.String("fix", "", "Auto-fix mode: dry-run (print diffs) or apply (rewrite files with a .bak backup)")
+	diffBase := flag.String("diff-base", "", "Only report findings on lines changed relative to this git ref")
+	diffOnly := flag.Bool("diff-only", false, "Shorthand for -diff-base=origin/main")
 	flag.Parse()
 
-	if err := runMigrationCheck(*dir, *command); err != nil {
+	if *diffBase == "" && *diffOnly {
+		*diffBase = "origin/main"
+	}
+
+	logger, err := newLogger(*logLevel, *logFormat)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	opts := migrationCheckOptions{
+		dir:                *dir,
+		customBuildCommand: *command,
+		format:             *format,
+		output:             *output,
+		cacheDir:           *cacheDir,
+		noCache:            *noCache,
+		refreshCache:       *refreshCache,
+		baselinePath:       *baseline,
+		fixMode:            *fix,
+		diffBase:           *diffBase,
+	}
+
+	if err := runMigrationCheck(logger, opts); err != nil {
+		logger.Error("migration check failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+type migrationCheckOptions struct {
+	dir                string
+	customBuildCommand string
+	format             string
+	output             string
+	cacheDir           string
+	noCache            bool
+	refreshCache       bool
+	baselinePath       string
+	fixMode            string
+	diffBase           string
 }
 
-func runMigrationCheck(dir, customBuildCommand string) error {
+func runMigrationCheck(logger *slog.Logger, opts migrationCheckOptions) error {
+	var key string
+	if !opts.noCache {
+		var err error
+		key, err = cacheKey(opts.dir, codeQLPackRef, opts.customBuildCommand)
+		if err != nil {
+			logger.Warn("failed to compute cache key, continuing without cache", "error", err)
+		} else if !opts.refreshCache {
+			if rawSarif, results, ok := loadCachedSarif(opts.cacheDir, key); ok {
+				logger.Info("cache hit, skipping CodeQL analysis", "cache_dir", opts.cacheDir, "key", key)
+				return reportResults(logger, opts, rawSarif, results)
+			}
+		}
+	}
+
 	dbPath, err := os.MkdirTemp(os.TempDir(), "cosmos-migration-db")
 
 	if err != nil {
@@ -81,63 +151,153 @@ func runMigrationCheck(dir, customBuildCommand string) error {
 		"database",
 		"create",
 		"--language=go",
-		"--source-root", dir,
+		"--source-root", opts.dir,
 	}
 
-	if customBuildCommand != "" {
-		command = append(command, "--command", customBuildCommand)
-		fmt.Println("Using custom build command:", customBuildCommand)
+	if opts.customBuildCommand != "" {
+		command = append(command, "--command", opts.customBuildCommand)
+		logger.Info("using custom build command", "command", opts.customBuildCommand)
 	}
 
 	command = append(command, dbPath)
 
-	fmt.Println(command)
+	logger.Debug("running command", "args", command)
 
 	cmd := exec.Command(command[0], command[1:]...)
 
-	if err := cmd.Run(); err != nil {
+	if err := runLoggedCommand(logger, cmd, "create", dbPath); err != nil {
 		return err
 	}
 
-	results, err := runAnalysis(dbPath)
+	rawSarif, results, err := runAnalysis(logger, dbPath)
+	if err != nil {
+		return err
+	}
+
+	if !opts.noCache && key != "" {
+		storeCachedSarif(logger, opts.cacheDir, key, rawSarif)
+	}
+
+	return reportResults(logger, opts, rawSarif, results)
+}
+
+// reportResults applies //nomigrate, baseline and -diff-base filtering to
+// the raw analysis results, then writes the surviving findings in
+// opts.format (or, in -fix mode, hands the //nomigrate-filtered results
+// to runFix instead). It returns an error (without failing to write the
+// report first) when findings remain that aren't in an active baseline
+// yet, or that lie on lines changed relative to opts.diffBase.
+func reportResults(logger *slog.Logger, opts migrationCheckOptions, rawSarif []byte, sarif *Sarif) error {
+	allFindings := sarifToFindings(sarif)
+	findings := filterNoMigrateDirectives(logger, allFindings)
+	filtered := len(findings) != len(allFindings)
+
+	if opts.fixMode != "" {
+		if opts.fixMode != "dry-run" && opts.fixMode != "apply" {
+			return fmt.Errorf("unknown -fix mode %q, want dry-run or apply", opts.fixMode)
+		}
+		return runFix(logger, filterSarifByFindings(sarif, findings), opts.fixMode)
+	}
+
+	// A brand-new baseline is bootstrapped from the full, pre-diff-filter
+	// finding set: it's meant to record "the current set of findings"
+	// outright, not whatever a particular -diff-base run happens to see.
+	// Once a baseline exists, diff-scoping narrows findings down to
+	// changed lines before the baseline gate sees them, so the
+	// baseline/diff failures below are both derived from that same final
+	// set — a pre-existing, not-yet-baselined finding outside the
+	// changed lines is dropped here and so never fails either gate,
+	// matching the diff-scoped report that's actually written.
+	baselineActive := false
+	if opts.baselinePath != "" {
+		accepted, exists, err := loadBaseline(opts.baselinePath)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := writeBaseline(opts.baselinePath, findings); err != nil {
+				return err
+			}
+			logger.Info("wrote new baseline", "path", opts.baselinePath, "count", len(findings))
+			findings = nil
+			filtered = true
+		} else {
+			if opts.diffBase != "" {
+				ranges, err := changedLineRanges(opts.dir, opts.diffBase)
+				if err != nil {
+					return fmt.Errorf("failed to compute diff against %s: %w", opts.diffBase, err)
+				}
+				findings = filterByDiff(findings, ranges)
+			}
+			findings = filterAgainstBaseline(findings, accepted)
+			baselineActive = true
+			filtered = true
+		}
+	} else if opts.diffBase != "" {
+		ranges, err := changedLineRanges(opts.dir, opts.diffBase)
+		if err != nil {
+			return fmt.Errorf("failed to compute diff against %s: %w", opts.diffBase, err)
+		}
+		findings = filterByDiff(findings, ranges)
+		filtered = true
+	}
+
+	var baselineErr, diffErr error
+	if len(findings) > 0 {
+		if baselineActive {
+			baselineErr = fmt.Errorf("%d new finding(s) not present in baseline %s", len(findings), opts.baselinePath)
+		}
+		if opts.diffBase != "" {
+			diffErr = fmt.Errorf("%d finding(s) on lines changed relative to %s", len(findings), opts.diffBase)
+		}
+	}
+
+	reportSarif := sarif
+	if filtered {
+		reportSarif = filterSarifByFindings(sarif, findings)
+		rawSarif = nil
+	}
+
+	w, closeW, err := openOutput(opts.output)
 	if err != nil {
 		return err
 	}
+	defer closeW()
 
-	return printFindings(results)
+	if err := writeReport(opts.format, w, rawSarif, reportSarif); err != nil {
+		return err
+	}
+	return errors.Join(baselineErr, diffErr)
 }
 
-func runAnalysis(dbPath string) (*Sarif, error) {
+func runAnalysis(logger *slog.Logger, dbPath string) ([]byte, *Sarif, error) {
 	// Run CodeQL analysis with your custom pack
 	resultsPath := path.Join(dbPath, "results.json")
 	cmd := exec.Command("codeql", "database", "analyze",
 		"--format=sarif-latest",
 		fmt.Sprintf("--output=%s", resultsPath),
 		dbPath,
-		"skip-mev/cosmos-52-ql")
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+		codeQLPackRef)
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("analysis failed: %w", err)
+	if err := runLoggedCommand(logger, cmd, "analyze", dbPath); err != nil {
+		return nil, nil, fmt.Errorf("analysis failed: %w", err)
 	}
 
 	// Parse results
 	data, err := os.ReadFile(resultsPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var sarif Sarif
 	if err := json.Unmarshal(data, &sarif); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &sarif, nil
+	return data, &sarif, nil
 }
 
-func printFindings(sarif *Sarif) error {
+func printFindingsTo(w io.Writer, sarif *Sarif) error {
 	red := color.New(color.FgRed)
 	yellow := color.New(color.FgYellow)
 	for _, run := range sarif.Runs {
@@ -152,9 +312,9 @@ func printFindings(sarif *Sarif) error {
 				if err != nil {
 					return fmt.Errorf("failed to read file: %w", err)
 				}
-				fmt.Printf("%s:%d:%d: %s\n", uri, line, column, red.Sprint(result.Message.Text))
-				fmt.Printf("  %d: %s\n", line, code)
-				fmt.Println(strings.Repeat(" ", column) + yellow.Sprint("^"))
+				fmt.Fprintf(w, "%s:%d:%d: %s\n", uri, line, column, red.Sprint(result.Message.Text))
+				fmt.Fprintf(w, "  %d: %s\n", line, code)
+				fmt.Fprintln(w, strings.Repeat(" ", column)+yellow.Sprint("^"))
 			}
 		}
 	}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeReport renders the analysis results in the requested format and
+// writes them to w. rawSarif is the untouched CodeQL output and is used
+// as-is by the sarif format when present; once baseline or -diff-base
+// filtering has been applied, or //nomigrate filtering has dropped
+// findings, rawSarif is nil and the sarif format falls back to
+// re-marshaling the filtered Sarif struct instead.
+func writeReport(format string, w io.Writer, rawSarif []byte, sarif *Sarif) error {
+	switch format {
+	case "text", "":
+		return printFindingsTo(w, sarif)
+	case "sarif":
+		if rawSarif != nil {
+			_, err := w.Write(rawSarif)
+			return err
+		}
+		data, err := json.MarshalIndent(sarif, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "json":
+		findings := sarifToFindings(sarif)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case "junit-xml":
+		return writeJUnitReport(w, sarifToFindings(sarif))
+	case "github-actions":
+		return writeGitHubActionsReport(w, sarifToFindings(sarif))
+	case "sonarqube":
+		return writeSonarQubeReport(w, sarifToFindings(sarif))
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// openOutput resolves the -output flag into a writer and a cleanup
+// function. "stdout" and "stderr" map to the standard streams; anything
+// else is treated as a file path to create/truncate.
+func openOutput(output string) (io.Writer, func(), error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, func() {}, nil
+	case "stderr":
+		return os.Stderr, func() {}, nil
+	default:
+		f, err := os.Create(output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open output %q: %w", output, err)
+		}
+		return f, func() { f.Close() }, nil
+	}
+}
+
+// sarifToFindings flattens a Sarif run into the tool's stable Finding
+// schema, used by every format except "sarif" and "text".
+func sarifToFindings(sarif *Sarif) []Finding {
+	var findings []Finding
+	for _, run := range sarif.Runs {
+		for _, result := range run.Results {
+			for _, location := range result.Locations {
+				findings = append(findings, Finding{
+					Rule:     result.RuleId,
+					Message:  result.Message.Text,
+					File:     location.PhysicalLocation.ArtifactLocation.Uri,
+					Line:     location.PhysicalLocation.Region.StartLine,
+					Column:   location.PhysicalLocation.Region.StartColumn,
+					Severity: severityFromLevel(result.Level),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func severityFromLevel(level string) string {
+	switch level {
+	case "error", "warning", "note":
+		return level
+	case "":
+		return "warning"
+	default:
+		return level
+	}
+}
+
+func writeGitHubActionsReport(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		fmt.Fprintf(w, "::error file=%s,line=%d,col=%d::%s (%s)\n", f.File, f.Line, f.Column, f.Message, f.Rule)
+	}
+	return nil
+}
+
+// sonarIssue is one entry in SonarQube's generic issue import format.
+// https://docs.sonarsource.com/sonarqube/latest/analyzing-source-code/importing-external-issues/generic-issue-import-format/
+type sonarIssue struct {
+	EngineID        string        `json:"engineId"`
+	RuleID          string        `json:"ruleId"`
+	Severity        string        `json:"severity"`
+	Type            string        `json:"type"`
+	PrimaryLocation sonarLocation `json:"primaryLocation"`
+}
+
+type sonarLocation struct {
+	Message   string         `json:"message"`
+	FilePath  string         `json:"filePath"`
+	TextRange sonarTextRange `json:"textRange"`
+}
+
+type sonarTextRange struct {
+	StartLine int `json:"startLine"`
+}
+
+func writeSonarQubeReport(w io.Writer, findings []Finding) error {
+	issues := make([]sonarIssue, 0, len(findings))
+	for _, f := range findings {
+		issues = append(issues, sonarIssue{
+			EngineID: "cosmos-migration",
+			RuleID:   f.Rule,
+			Severity: sonarSeverity(f.Severity),
+			Type:     "CODE_SMELL",
+			PrimaryLocation: sonarLocation{
+				Message:  f.Message,
+				FilePath: f.File,
+				TextRange: sonarTextRange{
+					StartLine: f.Line,
+				},
+			},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Issues []sonarIssue `json:"issues"`
+	}{Issues: issues})
+}
+
+func sonarSeverity(severity string) string {
+	switch severity {
+	case "error":
+		return "BLOCKER"
+	case "warning":
+		return "MAJOR"
+	default:
+		return "MINOR"
+	}
+}
+
+// JUnit XML mirrors what CI test-report ingestion expects: one
+// testsuite containing one testcase per finding, with failures
+// recorded rather than the finding text embedded as pass/fail.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnitReport(w io.Writer, findings []Finding) error {
+	suite := junitTestSuite{
+		Name:     "cosmos-migration",
+		Tests:    len(findings),
+		Failures: len(findings),
+	}
+	for _, f := range findings {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      fmt.Sprintf("%s:%d", f.File, f.Line),
+			Classname: f.Rule,
+			Failure: &junitFailure{
+				Message: f.Message,
+				Text:    fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message),
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}